@@ -1,20 +1,32 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net"
+	nethttp "net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
 	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/BurntSushi/toml"
+	"github.com/ProtonMail/go-crypto/openpgp"
 	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
 	"github.com/go-git/go-git/v5/storage/memory"
+	xssh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
 )
 
 const (
@@ -27,8 +39,18 @@ var (
 	ErrInvalidDeployment  = errors.New("invalid deployment")
 	ErrMissingUsername    = errors.New("username must be specified when using password authentication")
 	ErrTokenAndPassword   = errors.New("cannot specify both password and token for authentication")
+	ErrMixedAuth          = errors.New("cannot mix HTTP and SSH authentication fields")
+	ErrUnsignedRevision   = errors.New("revision is not signed")
+	ErrUntrustedSigner    = errors.New("revision is signed by an untrusted key")
 )
 
+// scpLikeURL matches scp-like SSH URLs such as "git@host:owner/repo.git".
+var scpLikeURL = regexp.MustCompile(`^[^@/]+@[^:/]+:.+$`)
+
+func isSSHURL(url string) bool {
+	return strings.HasPrefix(url, "ssh://") || scpLikeURL.MatchString(url)
+}
+
 type Command []string
 
 func (c Command) String() string {
@@ -88,10 +110,13 @@ func sortDeploymentsNewestToOldest(deployments []Deployment) []Deployment {
 
 type Config struct {
 	Repo struct {
-		URL      string `toml:"url"`
-		Username string `toml:"username"`
-		Password string `toml:"password"`
-		Token    string `toml:"token"`
+		URL              string `toml:"url"`
+		Username         string `toml:"username"`
+		Password         string `toml:"password"`
+		Token            string `toml:"token"`
+		SSHKeyPath       string `toml:"ssh_key_path"`
+		SSHKeyPassphrase string `toml:"ssh_key_passphrase"`
+		KnownHostsPath   string `toml:"known_hosts_path"`
 	} `toml:"repo"`
 	Build struct {
 		Commands []Command `toml:"commands"`
@@ -99,14 +124,79 @@ type Config struct {
 	Systemd struct {
 		Unit string `toml:"unit"`
 	} `toml:"systemd"`
+	Verify struct {
+		Enabled        bool     `toml:"enabled"`
+		KeyringPath    string   `toml:"keyring_path"`
+		AllowedSigners []string `toml:"allowed_signers"`
+	} `toml:"verify"`
+	Fetch struct {
+		Depth         int    `toml:"depth"`
+		SingleBranch  bool   `toml:"single_branch"`
+		ReferenceRepo string `toml:"reference_repo"`
+	} `toml:"fetch"`
+	HealthCheck struct {
+		Command  Command `toml:"command"`
+		HTTPURL  string  `toml:"http_url"`
+		Timeout  string  `toml:"timeout"`
+		Retries  int     `toml:"retries"`
+		Interval string  `toml:"interval"`
+	} `toml:"healthcheck"`
+	Hooks struct {
+		PreFetch     []Command `toml:"pre_fetch"`
+		PostBuild    []Command `toml:"post_build"`
+		PreActivate  []Command `toml:"pre_activate"`
+		PostActivate []Command `toml:"post_activate"`
+	} `toml:"hooks"`
+}
+
+func (c Config) usesSSHAuth() bool {
+	return c.Repo.SSHKeyPath != "" || c.Repo.SSHKeyPassphrase != "" || c.Repo.KnownHostsPath != ""
 }
 
-func (c Config) CloneOptions() *git.CloneOptions {
+func (c Config) usesHTTPAuth() bool {
+	return c.Repo.Username != "" || c.Repo.Password != "" || c.Repo.Token != ""
+}
+
+// hostKeyCallback builds a callback that verifies remote host keys against
+// the configured known_hosts file, returning a clear error on mismatch.
+func hostKeyCallback(knownHostsPath string) (xssh.HostKeyCallback, error) {
+	callback, err := knownhosts.New(knownHostsPath)
+	if err != nil {
+		return nil, fmt.Errorf("error loading known_hosts file %s: %w", knownHostsPath, err)
+	}
+
+	return func(hostname string, remote net.Addr, key xssh.PublicKey) error {
+		err := callback(hostname, remote, key)
+		if err != nil {
+			return fmt.Errorf("host key verification failed for %s: %w", hostname, err)
+		}
+		return nil
+	}, nil
+}
+
+func (c Config) CloneOptions() (*git.CloneOptions, error) {
 	opts := git.CloneOptions{
 		URL: c.Repo.URL,
 	}
 
-	if c.Repo.Token != "" {
+	if isSSHURL(c.Repo.URL) {
+		auth, err := ssh.NewPublicKeysFromFile("git", c.Repo.SSHKeyPath, c.Repo.SSHKeyPassphrase)
+		if err != nil {
+			return nil, fmt.Errorf("error loading SSH key %s: %w", c.Repo.SSHKeyPath, err)
+		}
+
+		if c.Repo.KnownHostsPath != "" {
+			callback, err := hostKeyCallback(c.Repo.KnownHostsPath)
+			if err != nil {
+				return nil, err
+			}
+			auth.HostKeyCallbackHelper = ssh.HostKeyCallbackHelper{
+				HostKeyCallback: callback,
+			}
+		}
+
+		opts.Auth = auth
+	} else if c.Repo.Token != "" {
 		opts.Auth = &http.BasicAuth{
 			Username: "mfd",
 			Password: c.Repo.Token,
@@ -118,7 +208,28 @@ func (c Config) CloneOptions() *git.CloneOptions {
 		}
 	}
 
-	return &opts
+	if c.Fetch.Depth > 0 {
+		opts.Depth = c.Fetch.Depth
+	}
+	if c.Fetch.SingleBranch {
+		opts.SingleBranch = true
+	}
+
+	return &opts, nil
+}
+
+// fullSHA reports whether s looks like a complete 40-character hex SHA1
+// hash, as opposed to a branch name, tag name, or abbreviated hash.
+func fullSHA(s string) bool {
+	if len(s) != 40 {
+		return false
+	}
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return false
+		}
+	}
+	return true
 }
 
 // This function reads the configuration from a TOML string and returns a Config struct.
@@ -162,6 +273,9 @@ func readConfig(data string) (Config, error) {
 	if conf.Repo.Password != "" && conf.Repo.Username == "" {
 		return Config{}, ErrMissingUsername
 	}
+	if conf.usesSSHAuth() && conf.usesHTTPAuth() {
+		return Config{}, ErrMixedAuth
+	}
 
 	return conf, nil
 }
@@ -184,7 +298,9 @@ func usage() error {
 	fmt.Println("  list        List available deployments")
 	fmt.Println("  deploy      Resolve, fetch, build, and activate a revision")
 	fmt.Println("  resolve     Resolve a revision to a deployment")
+	fmt.Println("  verify      Verify that a revision is signed and trusted")
 	fmt.Println("  rollback    Rollback to the previous deployment")
+	fmt.Println("  health      Run the healthcheck against the active deployment")
 	fmt.Println("  clean       Remove old, non-active deployments")
 	fmt.Println("  help        Show this help message")
 	return nil
@@ -281,48 +397,237 @@ func (mfd *MFD) List() error {
 }
 
 func (mfd *MFD) Activate(deployment Deployment) error {
-	link, err := os.Lstat(ActiveDeploymentSymlinkName)
+	// Create the new symlink under a temporary name and rename it into
+	// place, which is atomic on POSIX filesystems: any process reading
+	// ActiveDeploymentSymlinkName concurrently always sees either the old
+	// or the new target, never a missing or dangling link.
+	tmp := fmt.Sprintf("%s.tmp.%d.%d", ActiveDeploymentSymlinkName, os.Getpid(), time.Now().UnixNano())
+
+	err := os.Symlink(deployment.String(), tmp)
 	if err != nil {
-		// If the symlink does not exist, we'll soon create it.
-		// This code only returns other, non-not-exist errors.
-		if !errors.Is(err, os.ErrNotExist) {
-			return err
+		return fmt.Errorf("error creating temporary symlink: %w", err)
+	}
+
+	err = os.Rename(tmp, ActiveDeploymentSymlinkName)
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("error swapping active symlink: %w", err)
+	}
+
+	return nil
+}
+
+// findAnnotatedTag scans the repo's tags for an annotated tag object whose
+// target is hash, returning nil if none points at it. ResolveRevision always
+// peels annotated tags down to their target commit, so by the time
+// verifyRevision sees hash it's already a commit hash, not a tag object
+// hash: repo.TagObject(hash) would never find it. Scanning the tag refs
+// instead works regardless of how hash was obtained.
+func findAnnotatedTag(repo *git.Repository, hash plumbing.Hash) (*object.Tag, error) {
+	refs, err := repo.Tags()
+	if err != nil {
+		return nil, fmt.Errorf("error listing tags: %w", err)
+	}
+	defer refs.Close()
+
+	var found *object.Tag
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		tag, err := repo.TagObject(ref.Hash())
+		if err != nil {
+			// Lightweight tag; its ref already points at the commit.
+			return nil
+		}
+		if tag.Target == hash {
+			found = tag
+			return storer.ErrStop
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
+
+// verifyRevision checks that hash is signed by a trusted key, per the
+// [verify] config section. It looks for an annotated tag pointing at hash
+// first and falls back to the commit object otherwise. It is a no-op when
+// verification is disabled.
+func (mfd *MFD) verifyRevision(repo *git.Repository, hash plumbing.Hash) error {
+	if !mfd.conf.Verify.Enabled {
+		return nil
+	}
+
+	keyRing, err := os.ReadFile(mfd.conf.Verify.KeyringPath)
+	if err != nil {
+		return fmt.Errorf("error reading keyring %s: %w", mfd.conf.Verify.KeyringPath, err)
+	}
+
+	tag, err := findAnnotatedTag(repo, hash)
+	if err != nil {
+		return err
+	}
+
+	var entity *openpgp.Entity
+	if tag != nil {
+		entity, err = tag.Verify(string(keyRing))
 	} else {
-		// If the symlink already exists, remove it.
-		// NOTE: There is technically a small race condition here between
-		// removing the current symlink and creating the new one.
-		err = os.Remove(link.Name())
+		var commit *object.Commit
+		commit, err = repo.CommitObject(hash)
 		if err != nil {
-			return err
+			return fmt.Errorf("error loading commit %s: %w", hash, err)
+		}
+		if commit.PGPSignature == "" {
+			return fmt.Errorf("%w: %s", ErrUnsignedRevision, hash)
+		}
+		entity, err = commit.Verify(string(keyRing))
+	}
+	if err != nil {
+		return fmt.Errorf("error verifying signature of %s: %w", hash, err)
+	}
+
+	if len(mfd.conf.Verify.AllowedSigners) > 0 {
+		fingerprint := fmt.Sprintf("%X", entity.PrimaryKey.Fingerprint)
+		if !slices.Contains(mfd.conf.Verify.AllowedSigners, fingerprint) {
+			return fmt.Errorf("%w: %s", ErrUntrustedSigner, fingerprint)
 		}
 	}
 
-	return os.Symlink(deployment.String(), ActiveDeploymentSymlinkName)
+	return nil
 }
 
-func (mfd *MFD) Fetch(deployment Deployment) error {
-	repo, err := git.PlainClone(deployment.String(), false, mfd.conf.CloneOptions())
+// linkAlternateObjects points the deployment's object store at
+// reference_repo's objects, like `git clone --reference`, so objects it
+// already has on disk don't need to be re-downloaded.
+func linkAlternateObjects(dir, referenceRepo string) error {
+	objectsDir := filepath.Join(referenceRepo, ".git", "objects")
+	if _, err := os.Stat(objectsDir); errors.Is(err, os.ErrNotExist) {
+		objectsDir = filepath.Join(referenceRepo, "objects")
+	}
+
+	alternatesPath := filepath.Join(dir, ".git", "objects", "info", "alternates")
+	err := os.MkdirAll(filepath.Dir(alternatesPath), 0o755)
 	if err != nil {
-		if errors.Is(err, git.ErrRepositoryAlreadyExists) {
-			return nil
-		}
-		return fmt.Errorf("error cloning repository: %w", err)
+		return fmt.Errorf("error preparing alternates for %s: %w", dir, err)
 	}
 
-	w, err := repo.Worktree()
+	return os.WriteFile(alternatesPath, []byte(objectsDir+"\n"), 0o644)
+}
+
+// cloneDeployment clones the configured repository into the deployment
+// directory. When [fetch].reference_repo is set, it inits the repo and
+// links its object store before fetching instead of using a plain clone,
+// the same way `git clone --reference` borrows objects.
+func (mfd *MFD) cloneDeployment(deployment Deployment, opts *git.CloneOptions) (*git.Repository, error) {
+	if mfd.conf.Fetch.ReferenceRepo == "" {
+		return git.PlainClone(deployment.String(), false, opts)
+	}
+
+	repo, err := git.PlainInit(deployment.String(), false)
 	if err != nil {
-		return fmt.Errorf("error getting worktree: %w", err)
+		return nil, err
+	}
+
+	err = linkAlternateObjects(deployment.String(), mfd.conf.Fetch.ReferenceRepo)
+	if err != nil {
+		return nil, err
 	}
 
-	err = w.Checkout(&git.CheckoutOptions{
-		Hash: plumbing.NewHash(deployment.CommitHash),
+	_, err = repo.CreateRemote(&gitconfig.RemoteConfig{
+		Name: "origin",
+		URLs: []string{opts.URL},
 	})
 	if err != nil {
+		return nil, err
+	}
+
+	err = repo.Fetch(&git.FetchOptions{
+		RemoteName: "origin",
+		Auth:       opts.Auth,
+		Depth:      opts.Depth,
+		Tags:       git.AllTags,
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return nil, err
+	}
+
+	return repo, nil
+}
+
+// checkoutWithFallback checks out the deployment's commit, and when a
+// shallow or single-branch clone doesn't contain it, progressively widens
+// the fetch (doubling depth, then a full history fetch) until it does.
+func (mfd *MFD) checkoutWithFallback(deployment Deployment, repo *git.Repository, opts *git.CloneOptions) error {
+	w, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("error getting worktree: %w", err)
+	}
+
+	hash := plumbing.NewHash(deployment.CommitHash)
+	err = w.Checkout(&git.CheckoutOptions{Hash: hash})
+	if err == nil {
+		return nil
+	}
+	if !strings.Contains(err.Error(), "object not found") {
 		return fmt.Errorf("error checking out commit %s: %w", deployment.CommitHash, err)
 	}
 
-	return nil
+	depths := []int{}
+	if opts.Depth > 0 {
+		for d := opts.Depth * 2; d < opts.Depth*16; d *= 2 {
+			depths = append(depths, d)
+		}
+	}
+	depths = append(depths, 0) // 0 means fall back to the full history.
+
+	for _, depth := range depths {
+		err = repo.Fetch(&git.FetchOptions{
+			RemoteName: "origin",
+			RefSpecs:   []gitconfig.RefSpec{"+refs/heads/*:refs/remotes/origin/*"},
+			Auth:       opts.Auth,
+			Depth:      depth,
+			Tags:       git.AllTags,
+			Force:      true,
+		})
+		if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+			return fmt.Errorf("error widening fetch to depth %d: %w", depth, err)
+		}
+
+		err = w.Checkout(&git.CheckoutOptions{Hash: hash})
+		if err == nil {
+			return nil
+		}
+		if !strings.Contains(err.Error(), "object not found") {
+			return fmt.Errorf("error checking out commit %s: %w", deployment.CommitHash, err)
+		}
+	}
+
+	return fmt.Errorf("error checking out commit %s: %w", deployment.CommitHash, err)
+}
+
+func (mfd *MFD) Fetch(deployment Deployment) error {
+	opts, err := mfd.conf.CloneOptions()
+	if err != nil {
+		return fmt.Errorf("error building clone options: %w", err)
+	}
+
+	repo, err := mfd.cloneDeployment(deployment, opts)
+	if err != nil {
+		if errors.Is(err, git.ErrRepositoryAlreadyExists) {
+			return nil
+		}
+		return fmt.Errorf("error cloning repository: %w", err)
+	}
+
+	// Re-verify against the on-disk clone so a malicious mirror cannot
+	// substitute objects after the in-memory resolve.
+	err = mfd.verifyRevision(repo, plumbing.NewHash(deployment.CommitHash))
+	if err != nil {
+		return err
+	}
+
+	return mfd.checkoutWithFallback(deployment, repo, opts)
 }
 
 func (mfd *MFD) Build(deployment Deployment) error {
@@ -342,6 +647,38 @@ func (mfd *MFD) Build(deployment Deployment) error {
 	return nil
 }
 
+// runHooks executes a lifecycle hook's commands in order, streaming their
+// output the same way Build does. Each command sees the deployment's
+// directory as its working directory once it exists (pre_fetch hooks run
+// before the clone, in mfd's own directory) and the MFD_* variables
+// describing the in-flight deploy.
+func (mfd *MFD) runHooks(hooks []Command, deployment Deployment, previousDeployment string) error {
+	env := append(os.Environ(),
+		"MFD_COMMIT_HASH="+deployment.CommitHash,
+		"MFD_DEPLOYMENT_DIR="+deployment.String(),
+		"MFD_PREVIOUS_DEPLOYMENT="+previousDeployment,
+		"MFD_ACTIVE_SYMLINK="+ActiveDeploymentSymlinkName,
+	)
+
+	for _, command := range hooks {
+		cmd := exec.Command(command[0], command[1:]...)
+		if _, err := os.Stat(deployment.String()); err == nil {
+			cmd.Dir = deployment.String()
+		}
+		cmd.Env = env
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+
+		fmt.Println(command)
+		err := cmd.Run()
+		if err != nil {
+			return fmt.Errorf("error running hook command %s: %w", command.String(), err)
+		}
+	}
+
+	return nil
+}
+
 func (mfd *MFD) Restart() error {
 	if mfd.conf.Systemd.Unit == "" {
 		return nil
@@ -360,15 +697,129 @@ func (mfd *MFD) Restart() error {
 	return nil
 }
 
+// HealthCheck runs the configured [healthcheck] probe against deployment,
+// retrying on failure. It's a no-op when neither command nor http_url is
+// configured.
+func (mfd *MFD) HealthCheck(deployment Deployment) error {
+	hc := mfd.conf.HealthCheck
+	if len(hc.Command) == 0 && hc.HTTPURL == "" {
+		return nil
+	}
+
+	timeout := 5 * time.Second
+	if hc.Timeout != "" {
+		d, err := time.ParseDuration(hc.Timeout)
+		if err != nil {
+			return fmt.Errorf("error parsing healthcheck timeout %q: %w", hc.Timeout, err)
+		}
+		timeout = d
+	}
+
+	interval := time.Second
+	if hc.Interval != "" {
+		d, err := time.ParseDuration(hc.Interval)
+		if err != nil {
+			return fmt.Errorf("error parsing healthcheck interval %q: %w", hc.Interval, err)
+		}
+		interval = d
+	}
+
+	retries := hc.Retries
+	if retries <= 0 {
+		retries = 1
+	}
+
+	var err error
+	for attempt := 0; attempt < retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(interval)
+		}
+
+		if len(hc.Command) > 0 {
+			err = mfd.runHealthCheckCommand(deployment, hc.Command, timeout)
+		} else {
+			err = mfd.runHealthCheckHTTP(hc.HTTPURL, timeout)
+		}
+		if err == nil {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("healthcheck failed after %d attempt(s): %w", retries, err)
+}
+
+func (mfd *MFD) runHealthCheckCommand(deployment Deployment, command Command, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Dir = deployment.String()
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	fmt.Println(command)
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("healthcheck command %s failed: %w", command.String(), err)
+	}
+
+	return nil
+}
+
+func (mfd *MFD) runHealthCheckHTTP(url string, timeout time.Duration) error {
+	client := nethttp.Client{Timeout: timeout}
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return fmt.Errorf("healthcheck request to %s failed: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("healthcheck request to %s returned status %d", url, resp.StatusCode)
+	}
+
+	return nil
+}
+
 func (mfd *MFD) Deploy(commitHash string) error {
 	deployments, err := listDeployments()
 	if err != nil {
 		return err
 	}
 
+	var previousDeployment string
+	if active, activeErr := getActiveDeployment(); activeErr == nil {
+		previousDeployment = active.String()
+	} else if !errors.Is(activeErr, os.ErrNotExist) {
+		return activeErr
+	}
+
 	deployment, err := findDeploymentByCommitHash(deployments, commitHash)
 	if err == nil {
-		return mfd.Activate(deployment)
+		// Deployment already exists on disk, just run the same
+		// activate/restart hooks as a fresh deploy and health-check it.
+		err = mfd.runHooks(mfd.conf.Hooks.PreActivate, deployment, previousDeployment)
+		if err != nil {
+			return err
+		}
+
+		err = mfd.Activate(deployment)
+		if err != nil {
+			return err
+		}
+
+		err = mfd.Restart()
+		if err != nil {
+			return err
+		}
+
+		err = mfd.runHooks(mfd.conf.Hooks.PostActivate, deployment, previousDeployment)
+		if err != nil {
+			return err
+		}
+
+		return mfd.healthCheckOrRollback(deployment)
 	}
 
 	if !errors.Is(err, ErrDeploymentNotFound) {
@@ -377,6 +828,11 @@ func (mfd *MFD) Deploy(commitHash string) error {
 
 	deployment = NewDeployment(time.Now(), commitHash)
 
+	err = mfd.runHooks(mfd.conf.Hooks.PreFetch, deployment, previousDeployment)
+	if err != nil {
+		return err
+	}
+
 	err = mfd.Fetch(deployment)
 	if err != nil {
 		return err
@@ -387,6 +843,19 @@ func (mfd *MFD) Deploy(commitHash string) error {
 		return err
 	}
 
+	err = mfd.runHooks(mfd.conf.Hooks.PostBuild, deployment, previousDeployment)
+	if err != nil {
+		return err
+	}
+
+	// pre_activate runs with the new deployment built but the symlink
+	// still pointing at the old one, so it can run migrations or warm
+	// caches; a non-zero exit aborts the activation.
+	err = mfd.runHooks(mfd.conf.Hooks.PreActivate, deployment, previousDeployment)
+	if err != nil {
+		return err
+	}
+
 	err = mfd.Activate(deployment)
 	if err != nil {
 		return err
@@ -397,12 +866,51 @@ func (mfd *MFD) Deploy(commitHash string) error {
 		return err
 	}
 
-	return nil
+	err = mfd.runHooks(mfd.conf.Hooks.PostActivate, deployment, previousDeployment)
+	if err != nil {
+		return err
+	}
+
+	return mfd.healthCheckOrRollback(deployment)
+}
+
+// healthCheckOrRollback runs the configured healthcheck against deployment
+// and, if it fails, re-activates the previous deployment, restarts, and
+// returns a non-nil error so callers know the deploy was aborted.
+func (mfd *MFD) healthCheckOrRollback(deployment Deployment) error {
+	err := mfd.HealthCheck(deployment)
+	if err == nil {
+		return nil
+	}
+
+	rollbackErr := mfd.activatePrevious()
+	if rollbackErr != nil {
+		return fmt.Errorf("healthcheck failed: %w (rollback also failed: %v)", err, rollbackErr)
+	}
+
+	restartErr := mfd.Restart()
+	if restartErr != nil {
+		return fmt.Errorf("healthcheck failed: %w (rolled back, but restart failed: %v)", err, restartErr)
+	}
+
+	return fmt.Errorf("healthcheck failed, rolled back to previous deployment: %w", err)
 }
 
 func (mfd *MFD) Resolve(revision string) (string, error) {
+	opts, err := mfd.conf.CloneOptions()
+	if err != nil {
+		return "", fmt.Errorf("error building clone options: %w", err)
+	}
+
+	// Resolving a branch/tag name never needs full history, so default to
+	// a shallow, single-branch clone unless the config already set a depth.
+	if !fullSHA(revision) && opts.Depth == 0 {
+		opts.Depth = 1
+		opts.SingleBranch = true
+	}
+
 	// Perform an in-memory clone to find the expected commit / tag.
-	repo, err := git.Clone(memory.NewStorage(), nil, mfd.conf.CloneOptions())
+	repo, err := git.Clone(memory.NewStorage(), nil, opts)
 	if err != nil {
 		return "", fmt.Errorf("error performing in-memory clone: %w", err)
 	}
@@ -413,6 +921,12 @@ func (mfd *MFD) Resolve(revision string) (string, error) {
 		return "", fmt.Errorf("error resolving revision: %w", err)
 	}
 
+	// Abort before Fetch/Build/Activate if the revision isn't trusted.
+	err = mfd.verifyRevision(repo, *commitHash)
+	if err != nil {
+		return "", err
+	}
+
 	return commitHash.String(), nil
 }
 
@@ -446,7 +960,11 @@ func (mfd *MFD) Clean() error {
 	return nil
 }
 
-func (mfd *MFD) Rollback() error {
+// activatePrevious finds the deployment immediately older than the
+// currently active one and activates it. It's used both by the explicit
+// rollback command and by Deploy when a freshly-activated deployment
+// fails its health check.
+func (mfd *MFD) activatePrevious() error {
 	activeDeployment, err := getActiveDeployment()
 	if err != nil {
 		return err
@@ -481,6 +999,10 @@ func (mfd *MFD) Rollback() error {
 	return nil
 }
 
+func (mfd *MFD) Rollback() error {
+	return mfd.activatePrevious()
+}
+
 func run() error {
 	data, err := os.ReadFile("mfd.toml")
 	if err != nil {
@@ -533,8 +1055,37 @@ func run() error {
 
 		fmt.Printf("Resolved %s to %s\n", revision, commitHash)
 		return nil
+	case "verify":
+		revision := "HEAD"
+		if len(args) > 1 {
+			revision = args[1]
+		}
+
+		// Always check the signature here, even if [verify] is disabled
+		// for deploys.
+		mfd.conf.Verify.Enabled = true
+		commitHash, err := mfd.Resolve(revision)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Verified %s (%s)\n", revision, commitHash)
+		return nil
 	case "restart":
 		return mfd.Restart()
+	case "health":
+		activeDeployment, err := getActiveDeployment()
+		if err != nil {
+			return err
+		}
+
+		err = mfd.HealthCheck(activeDeployment)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("Healthy")
+		return nil
 	case "clean":
 		return mfd.Clean()
 	default:
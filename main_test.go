@@ -3,7 +3,11 @@ package main
 import (
 	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"slices"
 	"strings"
 	"testing"
@@ -145,6 +149,76 @@ func TestReadConfigTokenAndPassword(t *testing.T) {
 	}
 }
 
+func TestReadConfigMixedAuth(t *testing.T) {
+	t.Parallel()
+
+	url := "git@example.com:owner/repo.git"
+	data := fmt.Sprintf(`
+		[repo]
+		url = "%s"
+		token = "mytoken"
+		ssh_key_path = "/home/mfd/.ssh/id_ed25519"
+
+		[build]
+		commands = [
+			["true"],
+		]
+	`, url)
+
+	_, err := readConfig(data)
+	if err == nil {
+		t.Fatalf("Expected error reading config, got nil")
+	}
+
+	if !errors.Is(err, ErrMixedAuth) {
+		t.Errorf("Expected error to be ErrMixedAuth, got '%v'", err)
+	}
+}
+
+func TestIsSSHURL(t *testing.T) {
+	t.Parallel()
+
+	sshURLs := []string{
+		"git@github.com:theandrew168/mfd.git",
+		"ssh://git@github.com/theandrew168/mfd.git",
+	}
+	for _, url := range sshURLs {
+		if !isSSHURL(url) {
+			t.Errorf("Expected %s to be recognized as an SSH URL", url)
+		}
+	}
+
+	httpURLs := []string{
+		"https://github.com/theandrew168/mfd.git",
+		"http://example.com/repo.git",
+	}
+	for _, url := range httpURLs {
+		if isSSHURL(url) {
+			t.Errorf("Expected %s to not be recognized as an SSH URL", url)
+		}
+	}
+}
+
+func TestFullSHA(t *testing.T) {
+	t.Parallel()
+
+	if !fullSHA("a94a8fe5ccb19ba61c4c0873d391e987982fbbd3") {
+		t.Errorf("Expected a 40-character hex hash to be recognized as a full SHA")
+	}
+
+	notFullSHAs := []string{
+		"main",
+		"v1.2.3",
+		"a94a8fe",
+		"",
+	}
+	for _, s := range notFullSHAs {
+		if fullSHA(s) {
+			t.Errorf("Expected %q to not be recognized as a full SHA", s)
+		}
+	}
+}
+
 func TestReadConfigMissingUsername(t *testing.T) {
 	t.Parallel()
 
@@ -193,6 +267,155 @@ func TestFilesToDeployments(t *testing.T) {
 	}
 }
 
+func TestActivateAtomicSwap(t *testing.T) {
+	dir := t.TempDir()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	defer os.Chdir(wd)
+
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Failed to chdir to temp dir: %v", err)
+	}
+
+	mfd := NewMFD(Config{})
+
+	first := NewDeployment(time.Unix(1, 0), "a94a8fe5ccb19ba61c4c0873d391e987982fbbd3")
+	if err := mfd.Activate(first); err != nil {
+		t.Fatalf("Failed to activate initial deployment: %v", err)
+	}
+
+	stop := make(chan struct{})
+	errs := make(chan error, 1)
+
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+
+			link, err := os.Readlink(ActiveDeploymentSymlinkName)
+			if err != nil {
+				errs <- fmt.Errorf("reader observed missing symlink: %w", err)
+				return
+			}
+			if _, err := ParseDeployment(link); err != nil {
+				errs <- fmt.Errorf("reader observed dangling name %q: %w", link, err)
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < 200; i++ {
+		deployment := NewDeployment(time.Unix(int64(i), 0), "a94a8fe5ccb19ba61c4c0873d391e987982fbbd3")
+		if err := mfd.Activate(deployment); err != nil {
+			t.Fatalf("Failed to activate deployment: %v", err)
+		}
+	}
+	close(stop)
+
+	select {
+	case err := <-errs:
+		t.Fatal(err)
+	default:
+	}
+}
+
+func TestDeployExistingHealthCheckRollback(t *testing.T) {
+	dir := t.TempDir()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	defer os.Chdir(wd)
+
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Failed to chdir to temp dir: %v", err)
+	}
+
+	oldHash := strings.Repeat("a", 40)
+	newHash := strings.Repeat("b", 40)
+
+	oldDeployment := NewDeployment(time.Now().Add(-time.Hour), oldHash)
+	newDeployment := NewDeployment(time.Now(), newHash)
+
+	if err := os.Mkdir(oldDeployment.String(), 0o755); err != nil {
+		t.Fatalf("Failed to create old deployment dir: %v", err)
+	}
+	if err := os.Mkdir(newDeployment.String(), 0o755); err != nil {
+		t.Fatalf("Failed to create new deployment dir: %v", err)
+	}
+	if err := os.Symlink(oldDeployment.String(), ActiveDeploymentSymlinkName); err != nil {
+		t.Fatalf("Failed to create active symlink: %v", err)
+	}
+
+	conf := Config{}
+	conf.HealthCheck.HTTPURL = "http://127.0.0.1:1"
+	mfd := NewMFD(conf)
+
+	// newDeployment already exists on disk, so Deploy should take the
+	// activate/restart/health-check short-circuit rather than re-fetching.
+	err = mfd.Deploy(newHash)
+	if err == nil {
+		t.Fatalf("Expected Deploy to fail when the healthcheck fails")
+	}
+
+	link, err := os.Readlink(ActiveDeploymentSymlinkName)
+	if err != nil {
+		t.Fatalf("Failed to read active symlink: %v", err)
+	}
+	if link != oldDeployment.String() {
+		t.Errorf("Expected rollback to re-activate %s, got %s", oldDeployment.String(), link)
+	}
+}
+
+func TestDeployExistingRunsActivateHooks(t *testing.T) {
+	dir := t.TempDir()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	defer os.Chdir(wd)
+
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Failed to chdir to temp dir: %v", err)
+	}
+
+	hash := strings.Repeat("a", 40)
+	deployment := NewDeployment(time.Now(), hash)
+	if err := os.Mkdir(deployment.String(), 0o755); err != nil {
+		t.Fatalf("Failed to create deployment dir: %v", err)
+	}
+
+	preOutput := dir + "/pre_activate.txt"
+	postOutput := dir + "/post_activate.txt"
+
+	conf := Config{}
+	conf.Hooks.PreActivate = []Command{{"sh", "-c", "touch " + preOutput}}
+	conf.Hooks.PostActivate = []Command{{"sh", "-c", "touch " + postOutput}}
+	mfd := NewMFD(conf)
+
+	// deployment already exists on disk, so Deploy takes the
+	// activate/restart short-circuit; it should still run pre_activate and
+	// post_activate the same way a fresh deploy would.
+	if err := mfd.Deploy(hash); err != nil {
+		t.Fatalf("Failed to deploy: %v", err)
+	}
+
+	if _, err := os.Stat(preOutput); err != nil {
+		t.Errorf("Expected pre_activate hook to run, got: %v", err)
+	}
+	if _, err := os.Stat(postOutput); err != nil {
+		t.Errorf("Expected post_activate hook to run, got: %v", err)
+	}
+}
+
 func TestDeploymentString(t *testing.T) {
 	t.Parallel()
 
@@ -249,6 +472,399 @@ func TestParseDeploymentInvalid(t *testing.T) {
 	}
 }
 
+func TestHealthCheckHTTP(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	conf := Config{}
+	conf.HealthCheck.HTTPURL = server.URL
+	mfd := NewMFD(conf)
+
+	if err := mfd.HealthCheck(Deployment{}); err != nil {
+		t.Errorf("Expected healthy response, got error: %v", err)
+	}
+}
+
+func TestHealthCheckHTTPFailure(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	conf := Config{}
+	conf.HealthCheck.HTTPURL = server.URL
+	conf.HealthCheck.Retries = 2
+	conf.HealthCheck.Interval = "1ms"
+	mfd := NewMFD(conf)
+
+	if err := mfd.HealthCheck(Deployment{}); err == nil {
+		t.Error("Expected healthcheck to fail for a non-2xx response")
+	}
+}
+
+func TestHealthCheckNoop(t *testing.T) {
+	t.Parallel()
+
+	mfd := NewMFD(Config{})
+	if err := mfd.HealthCheck(Deployment{}); err != nil {
+		t.Errorf("Expected no-op healthcheck to succeed, got: %v", err)
+	}
+}
+
+func TestRunHooks(t *testing.T) {
+	dir := t.TempDir()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	defer os.Chdir(wd)
+
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Failed to chdir to temp dir: %v", err)
+	}
+
+	deployment := NewDeployment(time.Unix(1625079600, 0), "a94a8fe5ccb19ba61c4c0873d391e987982fbbd3")
+	if err := os.Mkdir(deployment.String(), 0o755); err != nil {
+		t.Fatalf("Failed to create deployment dir: %v", err)
+	}
+
+	mfd := NewMFD(Config{})
+
+	outputPath := dir + "/env.txt"
+	hooks := []Command{
+		{"sh", "-c", fmt.Sprintf("env > %s", outputPath)},
+	}
+	if err := mfd.runHooks(hooks, deployment, "previous-deployment"); err != nil {
+		t.Fatalf("Failed to run hooks: %v", err)
+	}
+
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read hook output: %v", err)
+	}
+
+	output := string(data)
+	for _, want := range []string{
+		"MFD_COMMIT_HASH=a94a8fe5ccb19ba61c4c0873d391e987982fbbd3",
+		"MFD_DEPLOYMENT_DIR=" + deployment.String(),
+		"MFD_PREVIOUS_DEPLOYMENT=previous-deployment",
+		"MFD_ACTIVE_SYMLINK=" + ActiveDeploymentSymlinkName,
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected hook environment to contain %q, got:\n%s", want, output)
+		}
+	}
+}
+
+// gpgKey is a throwaway GPG key generated in an isolated GNUPGHOME, along
+// with its exported public keyring, for signing test commits and tags.
+type gpgKey struct {
+	fingerprint string
+	keyringPath string
+	gnupgHome   string
+}
+
+// newGPGKey generates an unprotected ed25519 GPG key and exports its public
+// key to a keyring file that verifyRevision can load via Verify.KeyringPath.
+func newGPGKey(t *testing.T) gpgKey {
+	t.Helper()
+
+	if _, err := exec.LookPath("gpg"); err != nil {
+		t.Skip("gpg binary not available")
+	}
+
+	home := t.TempDir()
+	if err := os.Chmod(home, 0o700); err != nil {
+		t.Fatalf("Failed to chmod GNUPGHOME: %v", err)
+	}
+
+	spec := "%no-protection\n" +
+		"Key-Type: eddsa\n" +
+		"Key-Curve: ed25519\n" +
+		"Name-Real: mfd test\n" +
+		"Name-Email: mfd@example.com\n" +
+		"Expire-Date: 0\n" +
+		"%commit\n"
+	specPath := filepath.Join(home, "keyspec")
+	if err := os.WriteFile(specPath, []byte(spec), 0o600); err != nil {
+		t.Fatalf("Failed to write key spec: %v", err)
+	}
+
+	runGPG := func(args ...string) []byte {
+		cmd := exec.Command("gpg", args...)
+		cmd.Env = append(os.Environ(), "GNUPGHOME="+home)
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("gpg %v failed: %v\n%s", args, err, out)
+		}
+		return out
+	}
+
+	runGPG("--batch", "--gen-key", specPath)
+
+	listing := runGPG("--list-secret-keys", "--with-colons")
+
+	var fingerprint string
+	for _, line := range strings.Split(string(listing), "\n") {
+		if fields := strings.Split(line, ":"); fields[0] == "fpr" {
+			fingerprint = fields[9]
+			break
+		}
+	}
+	if fingerprint == "" {
+		t.Fatalf("Failed to find fingerprint in gpg output:\n%s", listing)
+	}
+
+	keyringPath := filepath.Join(home, "pub.asc")
+	armored := runGPG("--armor", "--export", fingerprint)
+	if err := os.WriteFile(keyringPath, armored, 0o644); err != nil {
+		t.Fatalf("Failed to write keyring: %v", err)
+	}
+
+	return gpgKey{fingerprint: fingerprint, keyringPath: keyringPath, gnupgHome: home}
+}
+
+// newTestRepo creates a bare repo with one commit on main, signed with key
+// iff signCommit, optionally tagged v1 with a separate, always-signed
+// annotated tag when addSignedTag.
+func newTestRepo(t *testing.T, key gpgKey, signCommit, addSignedTag bool) string {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git binary not available")
+	}
+
+	dir := t.TempDir()
+	bare := filepath.Join(dir, "repo.git")
+	work := filepath.Join(dir, "work")
+
+	run := func(dir string, args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GNUPGHOME="+key.gnupgHome)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	run(dir, "init", "--bare", "--initial-branch=main", bare)
+	run(dir, "clone", bare, work)
+	run(work, "config", "user.email", "mfd@example.com")
+	run(work, "config", "user.name", "mfd test")
+	run(work, "config", "gpg.program", "gpg")
+	run(work, "config", "user.signingkey", key.fingerprint)
+
+	if signCommit {
+		run(work, "commit", "--allow-empty", "-S", "-m", "initial commit")
+	} else {
+		run(work, "commit", "--allow-empty", "-m", "initial commit")
+	}
+	run(work, "push", "origin", "main")
+
+	if addSignedTag {
+		run(work, "tag", "-s", "v1", "-m", "signed tag")
+		run(work, "push", "origin", "v1")
+	}
+
+	return bare
+}
+
+func TestVerifyRevisionSignedTag(t *testing.T) {
+	key := newGPGKey(t)
+	// The commit itself is unsigned; only the tag pointing at it is, which
+	// exercises the tag-verification branch distinctly from the commit one.
+	bare := newTestRepo(t, key, false, true)
+
+	conf := Config{}
+	conf.Repo.URL = bare
+	conf.Verify.Enabled = true
+	conf.Verify.KeyringPath = key.keyringPath
+	conf.Verify.AllowedSigners = []string{key.fingerprint}
+	mfd := NewMFD(conf)
+
+	if _, err := mfd.Resolve("v1"); err != nil {
+		t.Fatalf("Expected a revision with a signed tag to verify, got: %v", err)
+	}
+}
+
+func TestVerifyRevisionSignedCommit(t *testing.T) {
+	key := newGPGKey(t)
+	bare := newTestRepo(t, key, true, false)
+
+	conf := Config{}
+	conf.Repo.URL = bare
+	conf.Verify.Enabled = true
+	conf.Verify.KeyringPath = key.keyringPath
+	conf.Verify.AllowedSigners = []string{key.fingerprint}
+	mfd := NewMFD(conf)
+
+	if _, err := mfd.Resolve("main"); err != nil {
+		t.Fatalf("Expected a signed commit to verify, got: %v", err)
+	}
+}
+
+func TestVerifyRevisionUntrustedSigner(t *testing.T) {
+	key := newGPGKey(t)
+	bare := newTestRepo(t, key, true, false)
+
+	conf := Config{}
+	conf.Repo.URL = bare
+	conf.Verify.Enabled = true
+	conf.Verify.KeyringPath = key.keyringPath
+	conf.Verify.AllowedSigners = []string{strings.Repeat("0", 40)}
+	mfd := NewMFD(conf)
+
+	_, err := mfd.Resolve("main")
+	if err == nil {
+		t.Fatalf("Expected Resolve to reject a signer outside allowed_signers")
+	}
+	if !errors.Is(err, ErrUntrustedSigner) {
+		t.Errorf("Expected ErrUntrustedSigner, got %v", err)
+	}
+}
+
+func TestVerifyRevisionUnsigned(t *testing.T) {
+	key := newGPGKey(t)
+	bare := newTestRepo(t, key, false, false)
+
+	conf := Config{}
+	conf.Repo.URL = bare
+	conf.Verify.Enabled = true
+	conf.Verify.KeyringPath = key.keyringPath
+	mfd := NewMFD(conf)
+
+	_, err := mfd.Resolve("main")
+	if err == nil {
+		t.Fatalf("Expected Resolve to reject an unsigned commit")
+	}
+	if !errors.Is(err, ErrUnsignedRevision) {
+		t.Errorf("Expected ErrUnsignedRevision, got %v", err)
+	}
+}
+
+// newMultiCommitBareRepo creates a bare repo with commits empty commits on
+// main and returns its path along with each commit's hash, oldest first.
+func newMultiCommitBareRepo(t *testing.T, commits int) (string, []string) {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git binary not available")
+	}
+
+	dir := t.TempDir()
+	bare := filepath.Join(dir, "repo.git")
+	work := filepath.Join(dir, "work")
+
+	run := func(dir string, args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	run(dir, "init", "--bare", "--initial-branch=main", bare)
+	run(dir, "clone", bare, work)
+	run(work, "config", "user.email", "mfd@example.com")
+	run(work, "config", "user.name", "mfd")
+
+	hashes := make([]string, commits)
+	for i := 0; i < commits; i++ {
+		run(work, "commit", "--allow-empty", "-m", fmt.Sprintf("commit %d", i))
+		hashes[i] = run(work, "rev-parse", "HEAD")
+	}
+	run(work, "push", "origin", "main")
+
+	return bare, hashes
+}
+
+func TestFetchShallowFallbackWidensDepth(t *testing.T) {
+	bare, hashes := newMultiCommitBareRepo(t, 5)
+	oldestHash := hashes[0]
+
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	defer os.Chdir(wd)
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Failed to chdir to temp dir: %v", err)
+	}
+
+	conf := Config{}
+	conf.Repo.URL = bare
+	conf.Fetch.Depth = 1
+	conf.Fetch.SingleBranch = true
+	mfd := NewMFD(conf)
+
+	// A depth-1, single-branch clone only contains the newest commit, so
+	// fetching the oldest one forces checkoutWithFallback to widen the
+	// fetch depth until it's reachable.
+	deployment := NewDeployment(time.Now(), oldestHash)
+	if err := mfd.Fetch(deployment); err != nil {
+		t.Fatalf("Expected Fetch to widen depth until %s is reachable, got: %v", oldestHash, err)
+	}
+
+	if _, err := os.Stat(filepath.Join(deployment.String(), ".git")); err != nil {
+		t.Fatalf("Expected deployment dir to contain a git worktree: %v", err)
+	}
+}
+
+func TestFetchReferenceRepoAlternates(t *testing.T) {
+	bare, hashes := newMultiCommitBareRepo(t, 1)
+	commitHash := hashes[0]
+
+	// A local clone standing in for a peer deployment's checkout that
+	// cloneDeployment can borrow objects from instead of re-downloading them.
+	referenceDir := filepath.Join(t.TempDir(), "reference")
+	cmd := exec.Command("git", "clone", bare, referenceDir)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to create reference clone: %v\n%s", err, out)
+	}
+
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	defer os.Chdir(wd)
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Failed to chdir to temp dir: %v", err)
+	}
+
+	conf := Config{}
+	conf.Repo.URL = bare
+	conf.Fetch.ReferenceRepo = referenceDir
+	mfd := NewMFD(conf)
+
+	deployment := NewDeployment(time.Now(), commitHash)
+	if err := mfd.Fetch(deployment); err != nil {
+		t.Fatalf("Expected Fetch to succeed using reference_repo alternates, got: %v", err)
+	}
+
+	alternatesPath := filepath.Join(deployment.String(), ".git", "objects", "info", "alternates")
+	data, err := os.ReadFile(alternatesPath)
+	if err != nil {
+		t.Fatalf("Expected alternates file to be written: %v", err)
+	}
+
+	wantObjectsDir := filepath.Join(referenceDir, ".git", "objects")
+	if !strings.Contains(string(data), wantObjectsDir) {
+		t.Errorf("Expected alternates file to point at %s, got: %s", wantObjectsDir, data)
+	}
+}
+
 func TestSortDeploymentsNewestToOldest(t *testing.T) {
 	t.Parallel()
 